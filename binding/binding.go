@@ -0,0 +1,342 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+// Package binding maps an incoming request body onto a struct and validates
+// it, so handlers can depend on a populated, typed argument instead of
+// reaching into *http.Request to decode it by hand.
+package binding
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Unknwon/macaron"
+)
+
+// MaxMemory bounds how much request body Json and MultipartForm take in: Json
+// stops reading a body longer than this, and MultipartForm buffers up to this
+// much in memory before spilling file parts to temporary files. Form does not
+// use it.
+var MaxMemory = int64(10 << 20) // 10 MB
+
+// Validator is implemented by structs that want custom validation beyond the
+// struct-tag-driven rules. It is called after the struct has been populated,
+// and may append to or replace the accumulated Errors.
+type Validator interface {
+	Validate(errors Errors, req *http.Request) Errors
+}
+
+// Bind wires up Json, Form, or MultipartForm depending on the request's
+// Content-Type header, so a single handler can be mounted regardless of how
+// the client chooses to send the body. A Content-Type that isn't JSON,
+// multipart/form-data, form-urlencoded, or empty is rejected with a
+// CONTENT_TYPE_ERROR instead of being guessed at.
+func Bind(obj interface{}) macaron.Handler {
+	return func(c *macaron.Context, req *http.Request) {
+		contentType := req.Header.Get("Content-Type")
+		switch {
+		case strings.Contains(contentType, "json"):
+			jsonHandler(obj, c, req)
+		case strings.Contains(contentType, "multipart/form-data"):
+			multipartFormHandler(obj, c, req)
+		case len(contentType) == 0 || strings.Contains(contentType, "form-urlencoded"):
+			formHandler(obj, c, req)
+		default:
+			ensureNotPointer(obj)
+			target := reflect.New(reflect.TypeOf(obj))
+			errors := make(Errors, 0)
+			errors.Add([]string{}, CONTENT_TYPE_ERROR, "Unsupported Content-Type: "+contentType)
+			validateAndMap(target, c, req, errors)
+		}
+	}
+}
+
+// Json decodes a JSON request body into a freshly-allocated value of obj's
+// type, and maps it (and the resulting Errors) into the context.
+func Json(obj interface{}) macaron.Handler {
+	return func(c *macaron.Context, req *http.Request) {
+		jsonHandler(obj, c, req)
+	}
+}
+
+func jsonHandler(obj interface{}, c *macaron.Context, req *http.Request) {
+	ensureNotPointer(obj)
+	target := reflect.New(reflect.TypeOf(obj))
+	errors := make(Errors, 0)
+
+	if req.Body != nil {
+		defer req.Body.Close()
+		decoder := json.NewDecoder(http.MaxBytesReader(nil, req.Body, MaxMemory))
+		if err := decoder.Decode(target.Interface()); err != nil {
+			errors.Add([]string{}, DESERIALIZATION_ERROR, err.Error())
+		}
+	}
+
+	validateAndMap(target, c, req, errors)
+}
+
+// Form parses an application/x-www-form-urlencoded (or a plain query-string)
+// body via req.ParseForm and maps it onto a freshly-allocated value of obj's
+// type using `form` struct tags.
+func Form(obj interface{}) macaron.Handler {
+	return func(c *macaron.Context, req *http.Request) {
+		formHandler(obj, c, req)
+	}
+}
+
+func formHandler(obj interface{}, c *macaron.Context, req *http.Request) {
+	ensureNotPointer(obj)
+	target := reflect.New(reflect.TypeOf(obj))
+	errors := make(Errors, 0)
+
+	if err := req.ParseForm(); err != nil {
+		errors.Add([]string{}, DESERIALIZATION_ERROR, err.Error())
+	} else {
+		errors = mapForm(target, req.Form, errors)
+	}
+
+	validateAndMap(target, c, req, errors)
+}
+
+// MultipartForm parses a multipart/form-data body via
+// req.ParseMultipartForm(MaxMemory) and maps both its values and its
+// *multipart.FileHeader slices onto a freshly-allocated value of obj's type
+// using `form` struct tags.
+func MultipartForm(obj interface{}) macaron.Handler {
+	return func(c *macaron.Context, req *http.Request) {
+		multipartFormHandler(obj, c, req)
+	}
+}
+
+func multipartFormHandler(obj interface{}, c *macaron.Context, req *http.Request) {
+	ensureNotPointer(obj)
+	target := reflect.New(reflect.TypeOf(obj))
+	errors := make(Errors, 0)
+
+	if err := req.ParseMultipartForm(MaxMemory); err != nil {
+		errors.Add([]string{}, DESERIALIZATION_ERROR, err.Error())
+	} else {
+		errors = mapForm(target, req.MultipartForm.Value, errors)
+		errors = mapFiles(target, req.MultipartForm.File, errors)
+	}
+
+	validateAndMap(target, c, req, errors)
+}
+
+// validateAndMap runs struct-tag-driven validation and any user-supplied
+// Validator, then maps the populated struct and the accumulated Errors into
+// the context.
+func validateAndMap(target reflect.Value, c *macaron.Context, req *http.Request, errors Errors) {
+	errors = validateStruct(target, errors)
+
+	if validator, ok := target.Interface().(Validator); ok {
+		errors = validator.Validate(errors, req)
+	}
+
+	c.Map(errors)
+	c.Map(target.Elem().Interface())
+}
+
+func ensureNotPointer(obj interface{}) {
+	if reflect.TypeOf(obj).Kind() == reflect.Ptr {
+		panic("Pointers are not accepted as binding models")
+	}
+}
+
+// mapForm walks the destination struct's fields and assigns values out of
+// form by matching each field's `form` tag (or its name, lower-cased).
+func mapForm(target reflect.Value, form map[string][]string, errors Errors) Errors {
+	typ := target.Elem().Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldValue := target.Elem().Field(i)
+
+		if !fieldValue.CanSet() {
+			continue
+		}
+
+		fieldName := field.Tag.Get("form")
+		if len(fieldName) == 0 {
+			fieldName = strings.ToLower(field.Name)
+		}
+
+		values, ok := form[fieldName]
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		if err := setWithProperType(field.Type.Kind(), values[0], fieldValue); err != nil {
+			errors.Add([]string{fieldName}, TYPE_ERROR, err.Error())
+		}
+	}
+
+	return errors
+}
+
+// mapFiles assigns uploaded *multipart.FileHeader slices onto []*multipart.FileHeader
+// fields whose `form` tag (or name) matches the multipart field name.
+func mapFiles(target reflect.Value, files map[string][]*multipart.FileHeader, errors Errors) Errors {
+	typ := target.Elem().Type()
+	fileHeaderSliceType := reflect.TypeOf([]*multipart.FileHeader{})
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldValue := target.Elem().Field(i)
+
+		if field.Type != fileHeaderSliceType || !fieldValue.CanSet() {
+			continue
+		}
+
+		fieldName := field.Tag.Get("form")
+		if len(fieldName) == 0 {
+			fieldName = strings.ToLower(field.Name)
+		}
+
+		if headers, ok := files[fieldName]; ok {
+			fieldValue.Set(reflect.ValueOf(headers))
+		}
+	}
+
+	return errors
+}
+
+func setWithProperType(kind reflect.Kind, val string, structField reflect.Value) error {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if len(val) == 0 {
+			val = "0"
+		}
+		intVal, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		structField.SetInt(intVal)
+	case reflect.Float32, reflect.Float64:
+		if len(val) == 0 {
+			val = "0"
+		}
+		floatVal, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		structField.SetFloat(floatVal)
+	case reflect.Bool:
+		if len(val) == 0 {
+			val = "false"
+		}
+		boolVal, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		structField.SetBool(boolVal)
+	case reflect.String:
+		structField.SetString(val)
+	default:
+		return fmt.Errorf("unsupported kind %s", kind)
+	}
+	return nil
+}
+
+// validateStruct enforces Required, Min, Max, and Pattern rules declared via
+// `binding` struct tags, e.g. `binding:"Required;Max(140)"`.
+func validateStruct(target reflect.Value, errors Errors) Errors {
+	typ := target.Elem().Type()
+	val := target.Elem()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		rules := field.Tag.Get("binding")
+		if len(rules) == 0 {
+			continue
+		}
+
+		fieldValue := val.Field(i)
+		fieldName := field.Tag.Get("form")
+		if len(fieldName) == 0 {
+			fieldName = strings.ToLower(field.Name)
+		}
+
+		for _, rule := range strings.Split(rules, ";") {
+			errors = applyRule(rule, fieldName, fieldValue, errors)
+		}
+	}
+
+	return errors
+}
+
+var ruleWithArg = regexp.MustCompile(`^(\w+)\(([^)]*)\)$`)
+
+func applyRule(rule, fieldName string, fieldValue reflect.Value, errors Errors) Errors {
+	name, arg := rule, ""
+	if m := ruleWithArg.FindStringSubmatch(rule); m != nil {
+		name, arg = m[1], m[2]
+	}
+
+	switch name {
+	case "Required":
+		if isZero(fieldValue) {
+			errors.Add([]string{fieldName}, REQUIRED_ERROR, "Required")
+		}
+	case "Min":
+		min, err := strconv.ParseFloat(arg, 64)
+		if err == nil && numericValue(fieldValue) < min {
+			errors.Add([]string{fieldName}, RANGE_ERROR, fmt.Sprintf("Below minimum of %s", arg))
+		}
+	case "Max":
+		max, err := strconv.ParseFloat(arg, 64)
+		if err == nil && numericValue(fieldValue) > max {
+			errors.Add([]string{fieldName}, RANGE_ERROR, fmt.Sprintf("Above maximum of %s", arg))
+		}
+	case "Pattern":
+		re, err := regexp.Compile(arg)
+		if err == nil && fieldValue.Kind() == reflect.String && !re.MatchString(fieldValue.String()) {
+			errors.Add([]string{fieldName}, PATTERN_ERROR, "Does not match pattern "+arg)
+		}
+	}
+
+	return errors
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Func, reflect.Chan:
+		return v.IsNil()
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.Interface() == reflect.Zero(v.Type()).Interface()
+	}
+}
+
+func numericValue(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return float64(len(v.String()))
+	default:
+		return 0
+	}
+}
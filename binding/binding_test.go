@@ -0,0 +1,243 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Unknwon/macaron"
+	"github.com/Unknwon/macaron/binding"
+)
+
+type post struct {
+	Title string `form:"title" binding:"Required"`
+}
+
+func TestForm_RequiredField(t *testing.T) {
+	m := macaron.New()
+	m.Post("/", binding.Form(post{}), func(w http.ResponseWriter, errs binding.Errors, p post) {
+		if errs.Has(binding.REQUIRED_ERROR) {
+			w.Write([]byte("missing title"))
+			return
+		}
+		w.Write([]byte("ok:" + p.Title))
+	})
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(""))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	if got := resp.Body.String(); got != "missing title" {
+		t.Errorf("expected a required-field error for an empty body, got %q", got)
+	}
+
+	req, _ = http.NewRequest("POST", "/", strings.NewReader(url.Values{"title": {"hello"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp = httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	if got := resp.Body.String(); got != "ok:hello" {
+		t.Errorf("expected the bound title, got %q", got)
+	}
+}
+
+type upload struct {
+	Files []*multipart.FileHeader `form:"files" binding:"Required"`
+}
+
+// TestMultipartForm_RequiredFileDoesNotPanic guards against isZero comparing
+// a []*multipart.FileHeader field via interface equality, which panics on any
+// slice-kind field carrying a `binding:"Required"` tag.
+func TestMultipartForm_RequiredFileDoesNotPanic(t *testing.T) {
+	m := macaron.New()
+	m.Post("/", binding.MultipartForm(upload{}), func(w http.ResponseWriter, errs binding.Errors) {
+		if errs.Has(binding.REQUIRED_ERROR) {
+			w.Write([]byte("missing file"))
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.Close()
+
+	req, _ := http.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp := httptest.NewRecorder()
+
+	m.ServeHTTP(resp, req)
+
+	if got := resp.Body.String(); got != "missing file" {
+		t.Errorf("expected a required-field error for a missing upload, got %q", got)
+	}
+}
+
+type article struct {
+	Title string `form:"title" binding:"Required;Max(20)"`
+	Score int    `form:"score" binding:"Min(1);Max(5)"`
+}
+
+func TestJson_BindsBody(t *testing.T) {
+	m := macaron.New()
+	m.Post("/", binding.Json(article{}), func(w http.ResponseWriter, a article) {
+		w.Write([]byte(a.Title))
+	})
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(`{"title":"hello","score":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+
+	if got := resp.Body.String(); got != "hello" {
+		t.Errorf("expected the bound JSON title, got %q", got)
+	}
+}
+
+func TestValidateStruct_MinMax(t *testing.T) {
+	m := macaron.New()
+	m.Post("/", binding.Form(article{}), func(w http.ResponseWriter, errs binding.Errors) {
+		if errs.Has(binding.RANGE_ERROR) {
+			w.Write([]byte("out of range"))
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(url.Values{
+		"title": {"hello"},
+		"score": {"9"},
+	}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+
+	if got := resp.Body.String(); got != "out of range" {
+		t.Errorf("expected a range error for score=9, got %q", got)
+	}
+}
+
+type slug struct {
+	Name string `form:"name" binding:"Pattern(^[a-z0-9-]+$)"`
+}
+
+func TestValidateStruct_Pattern(t *testing.T) {
+	m := macaron.New()
+	m.Post("/", binding.Form(slug{}), func(w http.ResponseWriter, errs binding.Errors) {
+		if errs.Has(binding.PATTERN_ERROR) {
+			w.Write([]byte("bad slug"))
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(url.Values{"name": {"Not A Slug!"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+
+	if got := resp.Body.String(); got != "bad slug" {
+		t.Errorf("expected a pattern error for an invalid slug, got %q", got)
+	}
+}
+
+type signup struct {
+	Password        string `form:"password"`
+	ConfirmPassword string `form:"confirm_password"`
+}
+
+func (s signup) Validate(errs binding.Errors, req *http.Request) binding.Errors {
+	if s.Password != s.ConfirmPassword {
+		errs.Add([]string{"confirm_password"}, "PasswordMismatch", "Passwords do not match")
+	}
+	return errs
+}
+
+func TestValidator_CustomHookRuns(t *testing.T) {
+	m := macaron.New()
+	m.Post("/", binding.Form(signup{}), func(w http.ResponseWriter, errs binding.Errors) {
+		if errs.Has("PasswordMismatch") {
+			w.Write([]byte("mismatch"))
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(url.Values{
+		"password":         {"hunter2"},
+		"confirm_password": {"hunter3"},
+	}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+
+	if got := resp.Body.String(); got != "mismatch" {
+		t.Errorf("expected the Validator hook to flag the mismatch, got %q", got)
+	}
+}
+
+func TestBind_UnknownContentType(t *testing.T) {
+	m := macaron.New()
+	m.Post("/", binding.Bind(post{}), func(w http.ResponseWriter, errs binding.Errors) {
+		if errs.Has(binding.CONTENT_TYPE_ERROR) {
+			w.Write([]byte("bad content type"))
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader("whatever"))
+	req.Header.Set("Content-Type", "application/xml")
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+
+	if got := resp.Body.String(); got != "bad content type" {
+		t.Errorf("expected a CONTENT_TYPE_ERROR for an unrecognized Content-Type, got %q", got)
+	}
+}
+
+func TestMultipartForm_MapsUploadedFiles(t *testing.T) {
+	m := macaron.New()
+	m.Post("/", binding.MultipartForm(upload{}), func(w http.ResponseWriter, errs binding.Errors, u upload) {
+		if errs.Len() > 0 || len(u.Files) != 1 {
+			w.Write([]byte("fail"))
+			return
+		}
+		w.Write([]byte(u.Files[0].Filename))
+	})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("files", "avatar.png")
+	if err != nil {
+		t.Fatalf("unexpected error creating form file: %v", err)
+	}
+	part.Write([]byte("fake-image-bytes"))
+	mw.Close()
+
+	req, _ := http.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+
+	if got := resp.Body.String(); got != "avatar.png" {
+		t.Errorf("expected the uploaded filename to be mapped, got %q", got)
+	}
+}
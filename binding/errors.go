@@ -0,0 +1,80 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+const (
+	// REQUIRED_ERROR classification is used when a required field has no value.
+	REQUIRED_ERROR = "RequiredError"
+	// CONTENT_TYPE_ERROR classification is used when Bind is given a
+	// Content-Type it does not recognize.
+	CONTENT_TYPE_ERROR = "ContentTypeError"
+	// DESERIALIZATION_ERROR classification is used when the request body could
+	// not be mapped onto the destination struct.
+	DESERIALIZATION_ERROR = "DeserializationError"
+	// TYPE_ERROR classification is used when a field value could not be
+	// converted to the destination field's type.
+	TYPE_ERROR = "TypeError"
+	// RANGE_ERROR classification is used when a field's value falls outside of
+	// the min/max bounds declared on the field.
+	RANGE_ERROR = "RangeError"
+	// PATTERN_ERROR classification is used when a field's value fails to match
+	// its declared regular expression.
+	PATTERN_ERROR = "PatternError"
+)
+
+// Error represents a single failure while binding or validating a request.
+type Error struct {
+	// FieldNames is the list of form field names this error applies to: each
+	// field's `form` tag, or its lower-cased struct field name.
+	// It is empty for errors that are not tied to a specific field (e.g. a
+	// malformed request body).
+	FieldNames []string
+	// Classification is one of the *_ERROR constants above, or a custom
+	// string supplied by a user-defined Validator.
+	Classification string
+	// Message is a human-readable description of the failure.
+	Message string
+}
+
+// Errors is the collection of binding/validation failures mapped into the
+// context for every request that goes through Bind, Json, Form, or
+// MultipartForm. Handlers can take it as an argument and decide whether to
+// short-circuit the request or render the problems back to the caller.
+type Errors []Error
+
+// Add appends a new error to the collection.
+func (e *Errors) Add(fieldNames []string, classification, message string) {
+	*e = append(*e, Error{
+		FieldNames:     fieldNames,
+		Classification: classification,
+		Message:        message,
+	})
+}
+
+// Len returns the number of accumulated errors.
+func (e Errors) Len() int {
+	return len(e)
+}
+
+// Has reports whether any error of the given classification has been
+// recorded.
+func (e Errors) Has(classification string) bool {
+	for _, err := range e {
+		if err.Classification == classification {
+			return true
+		}
+	}
+	return false
+}
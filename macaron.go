@@ -16,11 +16,15 @@
 package macaron
 
 import (
+	"bufio"
+	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -79,6 +83,15 @@ func (c *Context) Written() bool {
 	return c.rw.Written()
 }
 
+// Hijack proxies to the underlying ResponseWriter's Hijack method, taking
+// over the connection for a WebSocket or raw TCP upgrade. Once it returns
+// successfully, Written() reports true (via StatusHijacked) so the
+// middleware loop and handlers like Logger() treat the response as complete
+// instead of formatting a status/size or writing to the hijacked connection.
+func (c *Context) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return c.rw.Hijack()
+}
+
 func (c *Context) run() {
 	for c.index <= len(c.handlers) {
 		vals, err := c.Invoke(c.handler())
@@ -108,6 +121,13 @@ type Macaron struct {
 	action   Handler
 	*Router
 	logger *log.Logger
+
+	// ShutdownTimeout bounds how long RunContext waits for in-flight
+	// requests to finish once its context is canceled. Zero means wait
+	// indefinitely.
+	ShutdownTimeout time.Duration
+
+	server *http.Server
 }
 
 // New creates a bare bones Macaron instance.
@@ -162,6 +182,20 @@ func (m *Macaron) Use(handler Handler) {
 	m.handlers = append(m.handlers, handler)
 }
 
+// SetNotFoundHandler sets an injectable Handler to run, through the normal
+// dependency-injection pipeline, for requests that don't match any
+// registered route. It takes precedence over a Router.Fallback handler: use
+// this for requests the app wants to own, and Fallback for everything else.
+// A request it leaves unwritten goes on to Fallback.
+// Like Fallback, it configures the same underlying httprouter.Router.NotFound
+// dispatch as the older Router.NotFound method, so calling NotFound
+// afterwards will silently discard it.
+func (m *Macaron) SetNotFoundHandler(handler Handler) {
+	validateHandler(handler)
+	m.Router.notFound = handler
+	m.Router.buildNotFound()
+}
+
 func (m *Macaron) createContext(res http.ResponseWriter, req *http.Request) *Context {
 	c := &Context{
 		Injector: inject.New(),
@@ -185,6 +219,8 @@ func (m *Macaron) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 }
 
 // Run the http server. Listening on os.GetEnv("PORT") or 4000 by default.
+// It is a thin wrapper around RunContext for backward compatibility; use
+// RunContext, RunListener, RunUnix, or RunTLS directly for more control.
 func (m *Macaron) Run() {
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -196,7 +232,7 @@ func (m *Macaron) Run() {
 	logger := m.Injector.GetVal(reflect.TypeOf(m.logger)).Interface().(*log.Logger)
 
 	logger.Printf("listening on %s:%s (%s)\n", host, port, Env)
-	logger.Fatalln(http.ListenAndServe(host+":"+port, m))
+	logger.Fatalln(m.RunContext(context.Background(), host+":"+port))
 }
 
 // __________               __
@@ -221,18 +257,31 @@ func (r *Router) addRoute(method string, pattern string, handlers []Handler) {
 	}
 
 	r.router.Handle(method, pattern, func(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		if len(params) > 0 {
+			m := make(map[string]string, len(params))
+			for _, p := range params {
+				m[p.Key] = p.Value
+			}
+			req = SetURLParams(req, m)
+		}
+
 		c := r.m.createContext(resp, req)
 		c.params = params
-		c.handlers = handlers
+		// Merge in the global middleware stack registered via Macaron.Use,
+		// the same way Router.NotFound does, so handlers like RouterLogger
+		// still run for matched routes instead of being clobbered.
+		c.handlers = append(r.m.handlers, handlers...)
 		c.run()
 	})
 }
 
 type Router struct {
-	m      *Macaron
-	router *httprouter.Router
-	prefx  string
-	groups []group
+	m        *Macaron
+	router   *httprouter.Router
+	prefx    string
+	groups   []group
+	notFound Handler
+	fallback http.Handler
 }
 
 type group struct {
@@ -274,6 +323,15 @@ func (r *Router) Head(pattern string, h ...Handler) {
 	r.addRoute("HEAD", pattern, h)
 }
 
+// NotFound sets handlers to run, appended after the global middleware stack,
+// for requests that don't match any registered route.
+//
+// NotFound, Fallback, and Macaron.SetNotFoundHandler all configure the same
+// underlying httprouter.Router.NotFound dispatch. Fallback and
+// SetNotFoundHandler keep each other's setting, but calling NotFound replaces
+// both, and calling either of them after NotFound silently discards the
+// handlers given to NotFound. New code should prefer SetNotFoundHandler (which
+// coexists with Fallback) over calling NotFound directly.
 func (r *Router) NotFound(handlers ...Handler) {
 	r.router.NotFound = func(resp http.ResponseWriter, req *http.Request) {
 		c := r.m.createContext(resp, req)
@@ -282,6 +340,41 @@ func (r *Router) NotFound(handlers ...Handler) {
 	}
 }
 
+// Fallback sets h as the http.Handler invoked for any request that does not
+// match a registered route, once the app's own global middleware has run and
+// declined to write a response. This makes incremental migrations feasible
+// (e.g. moving routes out of Macaron into a sibling chi/mux-based router):
+// auth, logging, and recovery middleware still apply to the delegated
+// traffic, since they run before the fallback is ever reached.
+//
+// Fallback coexists with an injectable Handler set via
+// Macaron.SetNotFoundHandler: that Handler wins for requests the app wants to
+// own, and Fallback catches everything else. Both configure the same
+// underlying httprouter.Router.NotFound dispatch as the older Router.NotFound
+// method, though, so don't call NotFound after Fallback or
+// SetNotFoundHandler — it will silently replace them.
+func (r *Router) Fallback(h http.Handler) {
+	r.fallback = h
+	r.buildNotFound()
+}
+
+func (r *Router) buildNotFound() {
+	r.router.NotFound = http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		c := r.m.createContext(resp, req)
+
+		c.handlers = r.m.handlers
+		if r.notFound != nil {
+			validateHandler(r.notFound)
+			c.handlers = append(r.m.handlers, r.notFound)
+		}
+		c.run()
+
+		if !c.Written() && r.fallback != nil {
+			r.fallback.ServeHTTP(c.rw, req)
+		}
+	})
+}
+
 // \_   _____/ _______  __
 //  |    __)_ /    \  \/ /
 //  |        \   |  \   /
@@ -0,0 +1,105 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type urlParamsKey struct{}
+
+// Params returns all URL parameters captured for req by the route that
+// dispatched it, or an empty map if none were captured. This is useful for
+// generic middleware (CSRF, auth, rate limiting) that needs to inspect every
+// captured segment instead of asking Context.Params for one name at a time.
+func Params(req *http.Request) map[string]string {
+	if params, ok := req.Context().Value(urlParamsKey{}).(map[string]string); ok {
+		return params
+	}
+	return map[string]string{}
+}
+
+// SetURLParams returns a copy of req carrying params. It lets unit tests for
+// middleware synthesize a request with fake params instead of constructing
+// and dispatching through a whole Macaron instance.
+func SetURLParams(req *http.Request, params map[string]string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), urlParamsKey{}, params))
+}
+
+// patternMatcher compiles a macaron/httprouter-style pattern such as
+// "/user/:id" or "/static/*path" into a regular expression over the named
+// segments it captures.
+type patternMatcher struct {
+	re    *regexp.Regexp
+	names []string
+}
+
+func compilePattern(pattern string) *patternMatcher {
+	segments := strings.Split(pattern, "/")
+	names := make([]string, 0, len(segments))
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i, seg := range segments {
+		// A "*name" catch-all, like httprouter's own, captures the rest of
+		// the path including its leading "/"; it supplies its own separator
+		// instead of the "/" every other segment gets prefixed with.
+		if strings.HasPrefix(seg, "*") {
+			names = append(names, seg[1:])
+			b.WriteString("(/.*)")
+			continue
+		}
+
+		if i > 0 {
+			b.WriteString("/")
+		}
+
+		if strings.HasPrefix(seg, ":") {
+			names = append(names, seg[1:])
+			b.WriteString("([^/]+)")
+		} else {
+			b.WriteString(regexp.QuoteMeta(seg))
+		}
+	}
+	b.WriteString("$")
+
+	return &patternMatcher{re: regexp.MustCompile(b.String()), names: names}
+}
+
+func (p *patternMatcher) match(url string) (map[string]string, bool) {
+	m := p.re.FindStringSubmatch(url)
+	if m == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string, len(p.names))
+	for i, name := range p.names {
+		params[name] = m[i+1]
+	}
+	return params, true
+}
+
+// MatchTest reports whether url would match pattern, a macaron/httprouter-
+// style route pattern using ":name" and "*path" segments, without actually
+// dispatching a request through a Router. It's meant for generic middleware
+// that needs to test a pattern ahead of time (e.g. deciding whether a CSRF
+// exemption applies to the current path).
+func MatchTest(pattern, url string) bool {
+	_, ok := compilePattern(pattern).match(url)
+	return ok
+}
@@ -0,0 +1,86 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchTest(t *testing.T) {
+	cases := []struct {
+		pattern string
+		url     string
+		match   bool
+	}{
+		{"/user/:id", "/user/42", true},
+		{"/user/:id", "/user/42/extra", false},
+		{"/user/:id", "/user", false},
+		{"/static/*filepath", "/static/css/a.css", true},
+		{"/static/*filepath", "/static/", true},
+		{"/static/*filepath", "/other", false},
+	}
+
+	for _, c := range cases {
+		if got := MatchTest(c.pattern, c.url); got != c.match {
+			t.Errorf("MatchTest(%q, %q) = %v, want %v", c.pattern, c.url, got, c.match)
+		}
+	}
+}
+
+// TestCompilePattern_WildcardCapturesLeadingSlash pins down the catch-all
+// behavior to match httprouter's own *filepath semantics: the captured value
+// includes the leading "/", not just the segment after it.
+func TestCompilePattern_WildcardCapturesLeadingSlash(t *testing.T) {
+	params, ok := compilePattern("/static/*filepath").match("/static/css/a.css")
+	if !ok {
+		t.Fatal("expected the pattern to match")
+	}
+	if got := params["filepath"]; got != "/css/a.css" {
+		t.Errorf("expected filepath %q, got %q", "/css/a.css", got)
+	}
+}
+
+func TestParams_SetAndGet(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/user/42", nil)
+
+	if got := Params(req); len(got) != 0 {
+		t.Errorf("expected no params on a plain request, got %v", got)
+	}
+
+	req = SetURLParams(req, map[string]string{"id": "42"})
+	got := Params(req)
+	if got["id"] != "42" {
+		t.Errorf("expected id=42, got %v", got)
+	}
+}
+
+func TestAddRoute_StashesParamsOnRequest(t *testing.T) {
+	m := New()
+
+	var captured map[string]string
+	m.Get("/user/:id", func(req *http.Request) {
+		captured = Params(req)
+	})
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/user/42", nil)
+	m.ServeHTTP(resp, req)
+
+	if captured["id"] != "42" {
+		t.Errorf("expected the dispatched request to carry id=42, got %v", captured)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// hijackableRecorder wraps httptest.ResponseRecorder (which does not
+// implement http.Hijacker) with a working Hijack, so it can stand in for a
+// real net/http connection in tests.
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, _ := net.Pipe()
+	buf := bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server))
+	return server, buf, nil
+}
+
+func TestResponseWriter_Hijack(t *testing.T) {
+	rw := NewResponseWriter(&hijackableRecorder{httptest.NewRecorder()})
+
+	conn, _, err := rw.Hijack()
+	if err != nil {
+		t.Fatalf("unexpected error hijacking: %v", err)
+	}
+	defer conn.Close()
+
+	if rw.Status() != StatusHijacked {
+		t.Errorf("expected status %d after hijacking, got %d", StatusHijacked, rw.Status())
+	}
+	if !rw.Written() {
+		t.Error("expected Written() to report true once the connection is hijacked")
+	}
+}
+
+func TestResponseWriter_HijackUnsupported(t *testing.T) {
+	rw := NewResponseWriter(httptest.NewRecorder())
+
+	if _, _, err := rw.Hijack(); err == nil {
+		t.Fatal("expected an error hijacking a ResponseWriter that doesn't implement http.Hijacker")
+	}
+	if rw.Written() {
+		t.Error("a failed Hijack should not mark the response as written")
+	}
+}
+
+func TestContext_Hijack(t *testing.T) {
+	m := New()
+	res := &hijackableRecorder{httptest.NewRecorder()}
+	req, _ := http.NewRequest("GET", "/", nil)
+	c := m.createContext(res, req)
+
+	conn, _, err := c.Hijack()
+	if err != nil {
+		t.Fatalf("unexpected error hijacking via Context: %v", err)
+	}
+	defer conn.Close()
+
+	if !c.Written() {
+		t.Error("expected Context.Written() to report true once hijacked")
+	}
+}
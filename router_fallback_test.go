@@ -0,0 +1,83 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_Fallback(t *testing.T) {
+	var middlewareRan bool
+
+	sub := http.NewServeMux()
+	sub.HandleFunc("/legacy", func(w http.ResponseWriter, req *http.Request) {
+		if p := Params(req); len(p) != 0 {
+			t.Error("expected no params from the parent router to reach the fallback")
+		}
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("legacy"))
+	})
+
+	m := New()
+	m.Use(func() {
+		middlewareRan = true
+	})
+	m.Get("/known/:id", func() {})
+	m.Fallback(sub)
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/legacy", nil)
+	m.ServeHTTP(resp, req)
+
+	if !middlewareRan {
+		t.Error("expected global middleware to run before the fallback handler")
+	}
+	if resp.Code != http.StatusTeapot {
+		t.Errorf("expected status %d, got %d", http.StatusTeapot, resp.Code)
+	}
+	if resp.Body.String() != "legacy" {
+		t.Errorf("expected body %q, got %q", "legacy", resp.Body.String())
+	}
+}
+
+func TestRouter_SetNotFoundHandlerTakesPrecedence(t *testing.T) {
+	var fallbackRan bool
+
+	m := New()
+	m.SetNotFoundHandler(func(rw http.ResponseWriter) {
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte("owned"))
+	})
+	m.Fallback(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fallbackRan = true
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/anything", nil)
+	m.ServeHTTP(resp, req)
+
+	if fallbackRan {
+		t.Error("expected the injectable not-found Handler to win over Fallback")
+	}
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.Code)
+	}
+	if resp.Body.String() != "owned" {
+		t.Errorf("expected body %q, got %q", "owned", resp.Body.String())
+	}
+}
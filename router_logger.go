@@ -0,0 +1,110 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// routerLogContext is the value exposed to a RouterLogger template for every
+// request.
+type routerLogContext struct {
+	Req            *http.Request
+	Identity       string
+	Start          time.Time
+	ResponseWriter ResponseWriter
+	Latency        time.Duration
+}
+
+var routerLoggerFuncs = template.FuncMap{
+	"RemoteAddr": RemoteAddr,
+}
+
+// RemoteAddr returns the address the request came from, honouring
+// X-Real-IP and X-Forwarded-For so logs stay accurate behind a reverse proxy.
+func RemoteAddr(req *http.Request) string {
+	if ip := req.Header.Get("X-Real-IP"); len(ip) > 0 {
+		return ip
+	}
+	if ip := req.Header.Get("X-Forwarded-For"); len(ip) > 0 {
+		return strings.TrimSpace(strings.Split(ip, ",")[0])
+	}
+	return req.RemoteAddr
+}
+
+type signedUserNameKey struct{}
+
+// SetSignedUserName records name as the authenticated caller for req, so that
+// RouterLogger can report it via {{.Identity}}. Auth middleware should call
+// this as soon as it has identified the request and pass the returned
+// request along via c.Map.
+func SetSignedUserName(req *http.Request, name string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), signedUserNameKey{}, name))
+}
+
+func signedUserName(req *http.Request) string {
+	if name, ok := req.Context().Value(signedUserNameKey{}).(string); ok {
+		return name
+	}
+	return "-"
+}
+
+// RouterLogger returns a middleware handler that logs every request to
+// os.Stdout by executing tmpl as a text/template. The template is given a
+// routerLogContext exposing {{.Req}}, {{.Identity}}, {{.Start}},
+// {{.ResponseWriter.Status}}, {{.ResponseWriter.Size}}, and {{.Latency}}, plus
+// a {{RemoteAddr .Req}} helper. This lets operators produce Common Log
+// Format, Combined, or JSON lines without forking the middleware.
+func RouterLogger(tmpl string) Handler {
+	return RouterLoggerWithWriter(os.Stdout, tmpl)
+}
+
+// RouterLoggerWithWriter is like RouterLogger but writes to out instead of
+// os.Stdout.
+func RouterLoggerWithWriter(out io.Writer, tmpl string) Handler {
+	t := template.Must(template.New("router_logger").Funcs(routerLoggerFuncs).Parse(tmpl))
+
+	return func(c *Context, req *http.Request) {
+		start := time.Now()
+
+		c.Next()
+
+		// Auth middleware further down the chain identifies the caller by
+		// calling SetSignedUserName(req, name) and re-mapping the returned
+		// request with c.Map, since *http.Request is immutable. Pull the
+		// live value back out instead of logging the stale pointer this
+		// handler was invoked with.
+		if v := c.GetVal(reflect.TypeOf(req)); v.IsValid() {
+			if live, ok := v.Interface().(*http.Request); ok && live != nil {
+				req = live
+			}
+		}
+
+		t.Execute(out, routerLogContext{
+			Req:            req,
+			Identity:       signedUserName(req),
+			Start:          start,
+			ResponseWriter: c.rw,
+			Latency:        time.Since(start),
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterLoggerWithWriter_IdentityPropagation(t *testing.T) {
+	var out bytes.Buffer
+
+	m := New()
+	m.Use(RouterLoggerWithWriter(&out, "{{.Identity}} {{.ResponseWriter.Status}}"))
+	m.Get("/", func(c *Context, req *http.Request, w http.ResponseWriter) {
+		// Simulates auth middleware identifying the caller after
+		// RouterLogger has already run and captured the pre-auth request.
+		req = SetSignedUserName(req, "alice")
+		c.Map(req)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	m.ServeHTTP(resp, req)
+
+	if got := out.String(); got != "alice 200" {
+		t.Errorf("expected the identity set downstream to reach the log line, got %q", got)
+	}
+}
+
+func TestRouterLoggerWithWriter_NoIdentity(t *testing.T) {
+	var out bytes.Buffer
+
+	m := New()
+	m.Use(RouterLoggerWithWriter(&out, "{{.Identity}}"))
+	m.Get("/", func() {})
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	m.ServeHTTP(resp, req)
+
+	if got := out.String(); got != "-" {
+		t.Errorf("expected the default identity placeholder, got %q", got)
+	}
+}
+
+func TestRemoteAddr(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.5, 10.0.0.1")
+
+	if got := RemoteAddr(req); got != "203.0.113.5" {
+		t.Errorf("expected the first X-Forwarded-For hop, got %q", got)
+	}
+
+	req.Header.Set("X-Real-IP", "198.51.100.7")
+	if got := RemoteAddr(req); got != "198.51.100.7" {
+		t.Errorf("expected X-Real-IP to take precedence, got %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2014 Unknown
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package macaron
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+)
+
+// Server returns the *http.Server used by Run, RunListener, RunContext,
+// RunUnix, and RunTLS, creating it on first use. Configure ReadTimeout,
+// WriteTimeout, TLSConfig, MaxHeaderBytes, and similar fields on it before
+// calling one of the Run* methods.
+func (m *Macaron) Server() *http.Server {
+	if m.server == nil {
+		m.server = &http.Server{Handler: m}
+	}
+	return m.server
+}
+
+// RunListener serves on l, blocking until it is closed or returns an error.
+// It's the common pattern for sharing a pre-bound net.Listener, e.g. one
+// handed down by a socket-activated process manager.
+func (m *Macaron) RunListener(l net.Listener) error {
+	return m.Server().Serve(l)
+}
+
+// RunContext serves on addr until ctx is canceled, at which point it calls
+// Server().Shutdown to let in-flight requests drain before returning. The
+// shutdown is bounded by m.ShutdownTimeout, or unbounded if ShutdownTimeout
+// is zero.
+func (m *Macaron) RunContext(ctx context.Context, addr string) error {
+	server := m.Server()
+	server.Addr = addr
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownCtx := context.Background()
+		if m.ShutdownTimeout > 0 {
+			var cancel context.CancelFunc
+			shutdownCtx, cancel = context.WithTimeout(shutdownCtx, m.ShutdownTimeout)
+			defer cancel()
+		}
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		return ctx.Err()
+	}
+}
+
+// RunUnix serves on a Unix socket bound at path, set to the given file mode,
+// for socket-activated deploys behind a reverse proxy.
+func (m *Macaron) RunUnix(path string, mode os.FileMode) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		l.Close()
+		return err
+	}
+
+	return m.RunListener(l)
+}
+
+// RunTLS serves on addr using the given certificate and key files.
+func (m *Macaron) RunTLS(addr, cert, key string) error {
+	server := m.Server()
+	server.Addr = addr
+	return server.ListenAndServeTLS(cert, key)
+}